@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"archive/internal/archiver"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack <out> <src>",
+	Short: "Запаковать файл/папку в архив (формат определяется по расширению <out>: .zip, .tar, .tar.gz, .tar.zst, .tar.xz)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outPath, src := args[0], args[1]
+
+		format, err := archiver.FormatForName(outPath)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			return fmt.Errorf("указанный путь не существует: %s", src)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return err
+		}
+		out, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if err := archiver.ArchiveDir(context.Background(), format, src, out); err != nil {
+			return fmt.Errorf("ошибка при упаковке: %w", err)
+		}
+
+		fmt.Println("✅ Архив создан:", outPath)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"archive/internal/archiver"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// renderProgress печатает однострочный прогресс-бар в stderr, перезаписывая
+// предыдущую строку через \r. Используется как archiver.Options.Progress
+// командами zip/unzip при флаге --progress.
+func renderProgress(ev archiver.ProgressEvent) {
+	const width = 30
+
+	pct := 0.0
+	if ev.TotalBytes > 0 {
+		pct = float64(ev.BytesDone) / float64(ev.TotalBytes)
+	}
+	filled := int(pct * width)
+	if filled > width {
+		filled = width
+	}
+
+	bar := "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+	fmt.Fprintf(os.Stderr, "\r%s %3.0f%% (%d/%d) %s", bar, pct*100, ev.FilesDone, ev.TotalFiles, ev.CurrentFile)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"archive/internal/archiver"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var unpackDest string
+
+var unpackCmd = &cobra.Command{
+	Use:   "unpack <archive>",
+	Short: "-d = Распаковать архив в директорию (формат определяется по расширению, а если её нет — по магическим байтам)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		archivePath := args[0]
+
+		in, err := os.Open(archivePath)
+		if err != nil {
+			return fmt.Errorf("архив не найден: %s", archivePath)
+		}
+		defer in.Close()
+
+		format, r, err := archiver.DetectFormat(in, archivePath)
+		if err != nil {
+			return err
+		}
+
+		if unpackDest == "" {
+			base := filepath.Base(archivePath)
+			unpackDest = strings.TrimSuffix(base, format.Extension())
+		}
+
+		if err := archiver.ExtractTo(context.Background(), format, r, unpackDest); err != nil {
+			return fmt.Errorf("ошибка при распаковке: %w", err)
+		}
+
+		fmt.Println("✅ Распаковано в:", unpackDest)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unpackCmd)
+	unpackCmd.Flags().StringVarP(&unpackDest, "dest", "d", "", "директория распаковки")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"archive/internal/archiver"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 )
 
 var destDir string
+var unzipProgress bool
 
 var unzipCmd = &cobra.Command{
 	Use:   "unzip <zipfile>",
@@ -36,10 +38,18 @@ var unzipCmd = &cobra.Command{
 			destDir = base
 		}
 
+		opts := archiver.Options{}
+		if unzipProgress {
+			opts.Progress = renderProgress
+		}
+
 		// Запускаем распаковку
-		if err := archiver.UnzipParallelAll(zipfile, destDir); err != nil {
+		if err := archiver.UnzipParallelAllContext(context.Background(), zipfile, destDir, opts); err != nil {
 			return fmt.Errorf("ошибка при распаковке: %w", err)
 		}
+		if unzipProgress {
+			fmt.Fprintln(os.Stderr)
+		}
 
 		fmt.Println("✅ Распаковано в:", destDir)
 		return nil
@@ -49,4 +59,5 @@ var unzipCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(unzipCmd)
 	unzipCmd.Flags().StringVarP(&destDir, "dest", "d", "", "директория распаковки")
+	unzipCmd.Flags().BoolVar(&unzipProgress, "progress", false, "показывать прогресс-бар в stderr")
 }
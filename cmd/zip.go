@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"archive/internal/archiver"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,9 @@ import (
 )
 
 var outZip string
+var zipProgress bool
+var zipDeterministic bool
+var zipDedup bool
 
 var zipCmd = &cobra.Command{
 	Use:   "zip <src>",
@@ -29,10 +33,20 @@ var zipCmd = &cobra.Command{
 			outZip = base + ".zip"
 		}
 
+		opts := archiver.Options{}
+		if zipProgress {
+			opts.Progress = renderProgress
+		}
+		opts.Deterministic = zipDeterministic
+		opts.Dedup = zipDedup
+
 		// Запускаем упаковку
-		if err := archiver.ZipParallelAll(src, outZip); err != nil {
+		if err := archiver.ZipParallelAllContext(context.Background(), src, outZip, opts); err != nil {
 			return fmt.Errorf("ошибка при упаковке: %w", err)
 		}
+		if zipProgress {
+			fmt.Fprintln(os.Stderr)
+		}
 
 		fmt.Println("✅ Архив создан:", outZip)
 		return nil
@@ -42,4 +56,7 @@ var zipCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(zipCmd)
 	zipCmd.Flags().StringVarP(&outZip, "output", "o", "", "путь к выходному .zip")
+	zipCmd.Flags().BoolVar(&zipProgress, "progress", false, "показывать прогресс-бар в stderr")
+	zipCmd.Flags().BoolVar(&zipDeterministic, "deterministic", false, "воспроизводимый архив: одинаковый вход даёт побайтово одинаковый ZIP")
+	zipCmd.Flags().BoolVar(&zipDedup, "dedup", false, "дедуплицировать одинаковые по содержимому файлы внутри архива")
 }
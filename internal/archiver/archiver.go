@@ -0,0 +1,187 @@
+package archiver
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ZipOptions настраивает поведение Archiver при упаковке в ZIP.
+type ZipOptions struct {
+	// BlockSize — размер блока (в байтах), на которые режутся большие файлы
+	// для параллельного DEFLATE-сжатия. По умолчанию 1 MiB.
+	BlockSize int64
+	// MinParallelFileSize — минимальный размер файла, начиная с которого он
+	// режется на блоки и сжимается параллельно, а не одним потоком. По умолчанию 6 MiB.
+	MinParallelFileSize int64
+	// Concurrency — число горутин, используемых для блочного сжатия одного файла
+	// (и для обхода файлов в целом). По умолчанию runtime.NumCPU().
+	Concurrency int
+}
+
+// DefaultZipOptions возвращает настройки по умолчанию.
+func DefaultZipOptions() ZipOptions {
+	return ZipOptions{
+		BlockSize:           1 << 20, // 1 MiB
+		MinParallelFileSize: 6 << 20, // 6 MiB
+		Concurrency:         runtime.NumCPU(),
+	}
+}
+
+func (o ZipOptions) withDefaults() ZipOptions {
+	if o.BlockSize <= 0 {
+		o.BlockSize = 1 << 20
+	}
+	if o.MinParallelFileSize <= 0 {
+		o.MinParallelFileSize = 6 << 20
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.NumCPU()
+	}
+	return o
+}
+
+// Archiver упаковывает файлы в ZIP с настраиваемым параллелизмом.
+type Archiver struct {
+	Options ZipOptions
+	// Progress, если задан, вызывается после успешного завершения каждого
+	// файла в ArchiveFiles (см. ZipParallelAllContext).
+	Progress func(ProgressEvent)
+	// Deterministic включает воспроизводимый режим: см. Options.Deterministic.
+	Deterministic bool
+	// Dedup включает дедупликацию одинаковых файлов: см. Options.Dedup.
+	Dedup bool
+}
+
+// NewArchiver создаёт Archiver с заданными настройками, подставляя значения
+// по умолчанию для нулевых полей.
+func NewArchiver(opts ZipOptions) *Archiver {
+	return &Archiver{Options: opts.withDefaults()}
+}
+
+// flateWriterPool переиспользует *flate.Writer между блоками, чтобы не
+// выделять заново внутренние буферы компрессора на каждый блок.
+var flateWriterPool = sync.Pool{
+	New: func() interface{} {
+		fw, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return fw
+	},
+}
+
+// blockDeflate сжимает файл path блоками по opts.BlockSize параллельно и
+// склеивает сырые DEFLATE-потоки в один. Каждый блок, кроме последнего,
+// завершается SYNC_FLUSH (flate.Writer.Flush), чтобы следующий блок мог
+// быть дописан как продолжение потока; последний блок закрывается обычным
+// finalным блоком (flate.Writer.Close). Возвращает склеенные сжатые байты
+// и CRC32 всего файла.
+func blockDeflate(path string, size int64, opts ZipOptions) ([]byte, uint32, error) {
+	opts = opts.withDefaults()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	nBlocks := int((size + opts.BlockSize - 1) / opts.BlockSize)
+	if nBlocks == 0 {
+		nBlocks = 1
+	}
+
+	type blockResult struct {
+		data []byte
+		err  error
+	}
+	results := make([]blockResult, nBlocks)
+
+	workers := opts.Concurrency
+	if workers > nBlocks {
+		workers = nBlocks
+	}
+
+	jobs := make(chan int, nBlocks)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				off := int64(idx) * opts.BlockSize
+				ln := opts.BlockSize
+				if off+ln > size {
+					ln = size - off
+				}
+				buf := make([]byte, ln)
+				if _, err := f.ReadAt(buf, off); err != nil && err != io.EOF {
+					results[idx] = blockResult{err: err}
+					continue
+				}
+
+				var out bytes.Buffer
+				fw := flateWriterPool.Get().(*flate.Writer)
+				fw.Reset(&out)
+				if _, err := fw.Write(buf); err != nil {
+					flateWriterPool.Put(fw)
+					results[idx] = blockResult{err: err}
+					continue
+				}
+				if idx == nBlocks-1 {
+					err = fw.Close()
+				} else {
+					err = fw.Flush() // SYNC_FLUSH — поток можно продолжить следующим блоком
+				}
+				flateWriterPool.Put(fw)
+				if err != nil {
+					results[idx] = blockResult{err: err}
+					continue
+				}
+				results[idx] = blockResult{data: out.Bytes()}
+			}
+		}()
+	}
+
+	for i := 0; i < nBlocks; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var combined bytes.Buffer
+	for i := 0; i < nBlocks; i++ {
+		if results[i].err != nil {
+			return nil, 0, results[i].err
+		}
+		combined.Write(results[i].data)
+	}
+
+	hasher := crc32.NewIEEE()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, 0, err
+	}
+
+	return combined.Bytes(), hasher.Sum32(), nil
+}
+
+// writeRawDeflate пишет в zw уже сжатые блоком DEFLATE-данные через CreateRaw,
+// не прогоняя их ещё раз через компрессор zip.Writer.
+func writeRawDeflate(zw *zip.Writer, hdr *zip.FileHeader, compressed []byte, crc uint32, uncompressedSize uint64) error {
+	hdr.Method = zip.Deflate
+	hdr.CRC32 = crc
+	hdr.UncompressedSize64 = uncompressedSize
+	hdr.CompressedSize64 = uint64(len(compressed))
+
+	w, err := zw.CreateRaw(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(compressed)
+	return err
+}
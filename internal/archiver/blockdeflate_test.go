@@ -0,0 +1,57 @@
+package archiver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestZipParallelAllLargeFileBlockBoundary проверяет, что параллельное
+// блочное сжатие (blockDeflate/writeRawDeflate) даёт побитово верное
+// содержимое после распаковки — в том числе когда размер файла кратен
+// BlockSize (последний блок оказывается полным) и когда он на несколько байт
+// больше границы блока (последний блок из этих нескольких байт).
+func TestZipParallelAllLargeFileBlockBoundary(t *testing.T) {
+	opts := ZipOptions{BlockSize: 64 * 1024, MinParallelFileSize: 1, Concurrency: 4}
+
+	sizes := map[string]int{
+		"exact.bin":  2 * 64 * 1024,
+		"ragged.bin": 2*64*1024 + 17,
+	}
+
+	srcDir := t.TempDir()
+	want := make(map[string][]byte, len(sizes))
+	for name, size := range sizes {
+		data := make([]byte, size)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		want[name] = data
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	if err := NewArchiver(opts).ZipParallelAll(srcDir, zipPath); err != nil {
+		t.Fatalf("ZipParallelAll: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if err := UnzipParallelAll(zipPath, destDir); err != nil {
+		t.Fatalf("UnzipParallelAll: %v", err)
+	}
+
+	base := filepath.Base(srcDir)
+	for name, data := range want {
+		got, err := os.ReadFile(filepath.Join(destDir, base, name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: content mismatch after round-trip (got %d bytes, want %d)", name, len(got), len(data))
+		}
+	}
+}
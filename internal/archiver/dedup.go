@@ -0,0 +1,200 @@
+package archiver
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// dedupExtraTag — идентификатор собственного (не зарегистрированного в
+// PKWARE APPNOTE) extra-поля ZIP, которым помечается запись-дубликат при
+// Options.Dedup: байты 'D','E' как little-endian uint16.
+//
+// Формат поля (как у любого extra-поля ZIP): tag(2) + size(2) + data(size),
+// где data — имя канонической записи (NameInArchive) в UTF-8 без завершающего нуля.
+// Сама запись-дубликат при этом пишется как обычная пустая (Method=Store,
+// CRC32=0, UncompressedSize64=0) запись, поэтому сторонний распаковщик, не
+// знающий про тег dedupExtraTag, просто получит пустой файл вместо порчи архива.
+const dedupExtraTag uint16 = 0x4544
+
+// dedupExtraField строит extra-поле, указывающее на каноническую запись canonicalName.
+func dedupExtraField(canonicalName string) []byte {
+	data := []byte(canonicalName)
+	buf := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint16(buf[0:2], dedupExtraTag)
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(len(data)))
+	copy(buf[4:], data)
+	return buf
+}
+
+// parseDedupExtra ищет в extra-полях записи dedupExtraTag и возвращает имя
+// канонической записи, на которую она ссылается.
+func parseDedupExtra(extra []byte) (canonicalName string, ok bool) {
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			return "", false
+		}
+		if tag == dedupExtraTag {
+			return string(extra[4 : 4+size]), true
+		}
+		extra = extra[4+size:]
+	}
+	return "", false
+}
+
+// buildDedupIndex хэширует (SHA-256, параллельно в concurrency горутин)
+// каждый обычный файл из files и для записей, совпавших по размеру и хэшу с
+// уже встреченной ранее записью, возвращает NameInArchive первой (канонической)
+// из них. Каталоги и символические ссылки не участвуют в дедупликации.
+func buildDedupIndex(ctx context.Context, files []FileInfo, concurrency int) (map[int]string, error) {
+	regular := make([]int, 0, len(files))
+	for i, fi := range files {
+		if !fi.Info.IsDir() && fi.Info.Mode()&os.ModeSymlink == 0 {
+			regular = append(regular, i)
+		}
+	}
+	if len(regular) == 0 {
+		return nil, nil
+	}
+
+	jobs := concurrency
+	if jobs > len(regular) {
+		jobs = len(regular)
+	}
+
+	type hashResult struct {
+		idx int
+		key string
+		err error
+	}
+	jobsCh := make(chan int, len(regular))
+	resCh := make(chan hashResult, len(regular))
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobsCh {
+				fi := files[idx]
+				sum, err := sha256File(ctx, fi.Path)
+				if err != nil {
+					resCh <- hashResult{idx: idx, err: err}
+					continue
+				}
+				resCh <- hashResult{idx: idx, key: fmt.Sprintf("%d:%x", fi.Info.Size(), sum)}
+			}
+		}()
+	}
+	go func() {
+		for _, idx := range regular {
+			jobsCh <- idx
+		}
+		close(jobsCh)
+	}()
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
+
+	keys := make(map[int]string, len(regular))
+	for r := range resCh {
+		if r.err != nil {
+			return nil, r.err
+		}
+		keys[r.idx] = r.key
+	}
+
+	dupOf := make(map[int]string)
+	firstByKey := make(map[string]int, len(regular))
+	for _, idx := range regular {
+		key := keys[idx]
+		if first, seen := firstByKey[key]; seen {
+			dupOf[idx] = files[first].NameInArchive
+		} else {
+			firstByKey[key] = idx
+		}
+	}
+	return dupOf, nil
+}
+
+// sha256File считает SHA-256 содержимого файла path, уважая отмену ctx.
+func sha256File(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, ctxReader{ctx: ctx, r: f}); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// materializeDedupEntries раскладывает записи-дубликаты (отмеченные
+// dedupExtraTag) после того, как все обычные записи уже извлечены: каждая
+// такая запись либо жёстко линкуется, либо копируется с канонического файла,
+// уже лежащего в destDir. Жёсткая ссылка дешевле, но у неё общие с
+// оригиналом владелец/права/mtime — при неудаче (например, EXDEV) делаем
+// полную копию и восстанавливаем собственные mtime дубликата.
+func materializeDedupEntries(destDir string, entries []*zip.File) error {
+	for _, f := range entries {
+		canonicalName, ok := parseDedupExtra(f.Extra)
+		if !ok {
+			continue
+		}
+
+		canonicalPath := filepath.Join(destDir, canonicalName)
+		if err := ensureInside(destDir, canonicalPath); err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, f.Name)
+		if err := ensureInside(destDir, target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		_ = os.Remove(target) // на случай повторной распаковки поверх старого файла
+		if err := os.Link(canonicalPath, target); err == nil {
+			continue
+		}
+
+		if err := copyFile(canonicalPath, target, f.Mode()); err != nil {
+			return err
+		}
+		_ = os.Chtimes(target, time.Now(), f.Modified)
+	}
+	return nil
+}
+
+// copyFile копирует содержимое src в dst (создавая dst с правами mode).
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,73 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDedupMaterializeBothExtractionPaths проверяет, что дубликат, записанный
+// Options.Dedup как пустая запись с dedupExtraTag, корректно материализуется
+// с канонического файла обоими путями распаковки: UnzipParallelAllContext
+// (ZIP-специфичный, через materializeDedupEntries) и ExtractTo (общий
+// Format-путь через DetectFormat). Дубликат лежит в подкаталоге sub, а
+// каноническая запись — в корне архива.
+func TestDedupMaterializeBothExtractionPaths(t *testing.T) {
+	srcDir := t.TempDir()
+	content := []byte("duplicate content for dedup test")
+	if err := os.WriteFile(filepath.Join(srcDir, "a.bin"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "sub", "a_dup.bin"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	if err := ZipParallelAllContext(context.Background(), srcDir, zipPath, Options{Dedup: true}); err != nil {
+		t.Fatalf("ZipParallelAllContext: %v", err)
+	}
+	base := filepath.Base(srcDir)
+
+	t.Run("UnzipParallelAllContext", func(t *testing.T) {
+		destDir := t.TempDir()
+		if err := UnzipParallelAll(zipPath, destDir); err != nil {
+			t.Fatalf("UnzipParallelAll: %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(destDir, base, "sub", "a_dup.bin"))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("duplicate content mismatch: got %q, want %q", got, content)
+		}
+	})
+
+	t.Run("ExtractTo", func(t *testing.T) {
+		destDir := t.TempDir()
+		f, err := os.Open(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		format, r, err := DetectFormat(f, zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ExtractTo(context.Background(), format, r, destDir); err != nil {
+			t.Fatalf("ExtractTo: %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(destDir, base, "sub", "a_dup.bin"))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("duplicate content mismatch: got %q, want %q", got, content)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package archiver
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// deterministicEpoch возвращает фиксированный момент времени, которым в
+// Deterministic-режиме заменяется ModTime каждой записи: значение
+// SOURCE_DATE_EPOCH (unix-секунды), если оно задано и валидно, иначе Unix-эпоха.
+func deterministicEpoch() time.Time {
+	if v := os.Getenv("SOURCE_DATE_EPOCH"); v != "" {
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+	}
+	return time.Unix(0, 0).UTC()
+}
+
+// normalizedPerm возвращает нормализованные права доступа для
+// Deterministic-режима: владелец/группа/иные платформенные биты при этом не
+// переносятся вовсе, только этот фиксированный набор прав.
+func normalizedPerm(isDir bool) os.FileMode {
+	if isDir {
+		return 0o755
+	}
+	return 0o644
+}
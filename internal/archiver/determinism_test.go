@@ -0,0 +1,52 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestZipParallelAllDeterministic проверяет, что Options.Deterministic даёт
+// побайтово одинаковый ZIP для одинаковых по содержимому входных файлов, даже
+// если их mtime на диске отличается между прогонами.
+func TestZipParallelAllDeterministic(t *testing.T) {
+	build := func(aMTime time.Time) []byte {
+		// Имя srcDir попадает в архив как префикс пути, поэтому для обоих
+		// прогонов оно должно быть одинаковым ("src"), иначе сравниваемые
+		// архивы заведомо различаются не из-за Deterministic, а из-за имени
+		// временного каталога.
+		srcDir := filepath.Join(t.TempDir(), "src")
+		if err := os.MkdirAll(srcDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("hello"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, "b.txt"), []byte("world"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(filepath.Join(srcDir, "a.txt"), aMTime, aMTime); err != nil {
+			t.Fatal(err)
+		}
+
+		zipPath := filepath.Join(t.TempDir(), "out.zip")
+		opts := Options{Deterministic: true}
+		if err := ZipParallelAllContext(context.Background(), srcDir, zipPath, opts); err != nil {
+			t.Fatalf("ZipParallelAllContext: %v", err)
+		}
+		data, err := os.ReadFile(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := build(time.Unix(1_000_000, 0))
+	second := build(time.Unix(2_000_000, 0))
+	if !bytes.Equal(first, second) {
+		t.Fatalf("deterministic ZIP differs between runs with different input mtimes (%d vs %d bytes)", len(first), len(second))
+	}
+}
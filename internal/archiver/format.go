@@ -0,0 +1,266 @@
+package archiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SniffLen — сколько байт от начала потока нужно прочитать, чтобы
+// определить формат архива по магическим числам (см. FormatForMagic).
+// ustar-магия tar лежит на смещении 257 и занимает 5 байт, поэтому запас
+// должен доходить хотя бы до 262.
+const SniffLen = 512
+
+// FileInfo описывает один элемент, который нужно поместить в архив.
+type FileInfo struct {
+	// Path — путь к файлу на диске.
+	Path string
+	// NameInArchive — имя (путь) записи внутри архива, всегда с "/" в качестве разделителя.
+	NameInArchive string
+	// Info — результат Lstat(Path) (символические ссылки не разыменовываются), переиспользуется воркерами, чтобы не стать дважды.
+	Info os.FileInfo
+}
+
+// File — запись, извлечённая из архива и переданная в handler Format.Extract/WalkArchive.
+type File struct {
+	Name    string
+	Mode    fs.FileMode
+	ModTime time.Time
+	Size    int64
+	IsDir   bool
+	// Linkname — цель символической ссылки, если Mode&fs.ModeSymlink != 0
+	// (у tar-форматов она приходит отдельным полем заголовка). У ZIP цель
+	// ссылки вместо этого читается из Reader — там Linkname всегда пуст.
+	Linkname string
+	// Extra — сырое extra-поле записи (у ZIP). Может нести dedupExtraTag
+	// (см. dedup.go) — запись-дубликат, чьё тело нужно материализовать с
+	// канонической записи, а не читать из Reader (там 0 байт).
+	Extra []byte
+	// Reader читает содержимое записи. Валиден только на время вызова handler.
+	Reader io.Reader
+}
+
+// Format — реализация одного архивного формата (zip, tar, tar.gz, ...).
+type Format interface {
+	// Extension возвращает каноническое расширение формата вместе с точкой, например ".tar.gz".
+	Extension() string
+	// Match сообщает, соответствуют ли первые байты потока (магические числа) этому формату.
+	// magic может быть короче SniffLen, если поток сам оказался короче.
+	Match(magic []byte) bool
+	// Archive упаковывает files в out в этом формате.
+	Archive(ctx context.Context, out io.Writer, files []FileInfo) error
+	// Extract читает архив этого формата из in, вызывая handler для каждой записи по порядку.
+	Extract(ctx context.Context, in io.Reader, handler func(File) error) error
+}
+
+// formats — реестр поддерживаемых форматов. Порядок важен только для
+// FormatForName при совпадающих суффиксах, которых сейчас нет.
+var formats = []Format{
+	zipFormat{},
+	tarGzFormat{},
+	tarZstFormat{},
+	tarXzFormat{},
+	tarFormat{},
+}
+
+// FormatForName выбирает Format по расширению имени файла (например, "out.tar.zst").
+func FormatForName(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	for _, f := range formats {
+		if strings.HasSuffix(lower, f.Extension()) {
+			return f, nil
+		}
+	}
+	return nil, errors.New("архиватор: не удалось определить формат по имени " + name)
+}
+
+// FormatForMagic определяет формат архива по его магическим байтам —
+// первым до SniffLen байтам потока.
+func FormatForMagic(magic []byte) (Format, error) {
+	for _, f := range formats {
+		if f.Match(magic) {
+			return f, nil
+		}
+	}
+	return nil, errors.New("архиватор: не удалось определить формат по содержимому")
+}
+
+// DetectFormat определяет Format для потока in, именованного name: сперва по
+// расширению name, а если оно не распознано — по магическим байтам начала
+// потока. Возвращает Format и io.Reader, с которого нужно продолжить чтение
+// (он включает в себя уже подсмотренные для определения формата байты).
+func DetectFormat(in io.Reader, name string) (Format, io.Reader, error) {
+	if f, err := FormatForName(name); err == nil {
+		return f, in, nil
+	}
+
+	br := bufio.NewReaderSize(in, SniffLen)
+	magic, err := br.Peek(SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	f, err := FormatForMagic(magic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, br, nil
+}
+
+// CollectFiles обходит src и строит список FileInfo для упаковки Format.Archive.
+// Если src — каталог, её базовое имя становится корнем внутри архива; если
+// src — одиночный файл, внутри архива он лежит под своим базовым именем.
+// Порядок результата детерминирован (пути отсортированы).
+func CollectFiles(src string) ([]FileInfo, error) {
+	src = filepath.Clean(src)
+
+	top, err := os.Lstat(src)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := ""
+	if top.IsDir() {
+		baseDir = filepath.Base(src)
+	}
+
+	type walked struct {
+		path string
+		info os.FileInfo
+	}
+	entries := make([]walked, 0, 1024)
+	if err := filepath.WalkDir(src, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		info, err := d.Info() // Lstat-семантика: символические ссылки не разыменовываются
+		if err != nil {
+			return err
+		}
+		entries = append(entries, walked{path: p, info: info})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, e := range entries {
+		var rel string
+		switch {
+		case baseDir == "": // один файл
+			rel = filepath.Base(e.path)
+		case e.path == src:
+			rel = baseDir
+		default:
+			rel, err = filepath.Rel(filepath.Dir(src), e.path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		files = append(files, FileInfo{
+			Path:          e.path,
+			NameInArchive: filepath.ToSlash(rel),
+			Info:          e.info,
+		})
+	}
+	return files, nil
+}
+
+// ArchiveDir собирает файлы из src и упаковывает их в out форматом f.
+func ArchiveDir(ctx context.Context, f Format, src string, out io.Writer) error {
+	files, err := CollectFiles(src)
+	if err != nil {
+		return err
+	}
+	return f.Archive(ctx, out, files)
+}
+
+// ExtractTo извлекает все записи формата f из in в destDir, защищаясь от
+// zip-slip и восстанавливая права доступа и mtime записей.
+func ExtractTo(ctx context.Context, f Format, in io.Reader, destDir string) error {
+	if err := os.MkdirAll(destDir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	return f.Extract(ctx, in, func(file File) error {
+		target := filepath.Join(destDir, file.Name)
+		if err := ensureInside(destDir, target); err != nil {
+			return err
+		}
+
+		if file.IsDir {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		if file.Mode&fs.ModeSymlink != 0 {
+			linkTarget := file.Linkname
+			if linkTarget == "" {
+				data, err := io.ReadAll(file.Reader)
+				if err != nil {
+					return err
+				}
+				linkTarget = string(data)
+			}
+
+			// Символическая ссылка может указывать за пределы destDir тем же
+			// способом, что и сама запись (zip-slip) — проверяем разрешённый путь.
+			resolved := linkTarget
+			if !filepath.IsAbs(resolved) {
+				resolved = filepath.Join(filepath.Dir(target), resolved)
+			}
+			if err := ensureInside(destDir, resolved); err != nil {
+				return err
+			}
+
+			_ = os.Remove(target) // на случай повторной распаковки поверх старой ссылки/файла
+			return os.Symlink(linkTarget, target)
+		}
+
+		if canonicalName, ok := parseDedupExtra(file.Extra); ok {
+			// Дубликат (Options.Dedup при упаковке, см. dedup.go): тело нужно
+			// материализовать с канонической записи — она уже извлечена раньше,
+			// так как порядок записей в архиве это гарантирует.
+			canonicalPath := filepath.Join(destDir, canonicalName)
+			if err := ensureInside(destDir, canonicalPath); err != nil {
+				return err
+			}
+			_ = os.Remove(target) // на случай повторной распаковки поверх старого файла
+			if err := os.Link(canonicalPath, target); err == nil {
+				return nil
+			}
+			if err := copyFile(canonicalPath, target, file.Mode); err != nil {
+				return err
+			}
+			return os.Chtimes(target, time.Now(), file.ModTime)
+		}
+
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, file.Reader); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(target, time.Now(), file.ModTime)
+	})
+}
@@ -0,0 +1,111 @@
+package archiver
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// writeTarEntries пишет files как tar-поток в w. Используется всеми
+// tar-форматами (обычным и сжатыми) — разница между ними только в том, чем w
+// обёрнут снаружи (gzip/zstd/xz writer или сам файл).
+func writeTarEntries(ctx context.Context, w io.Writer, files []FileInfo) error {
+	tw := tar.NewWriter(w)
+	for _, fi := range files {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := writeTarEntry(tw, fi); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeTarEntry(tw *tar.Writer, fi FileInfo) error {
+	link := ""
+	if fi.Info.Mode()&os.ModeSymlink != 0 {
+		l, err := os.Readlink(fi.Path)
+		if err != nil {
+			return err
+		}
+		link = l
+	}
+
+	hdr, err := tar.FileInfoHeader(fi.Info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = fi.NameInArchive
+	if fi.Info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !fi.Info.Mode().IsRegular() {
+		// Каталоги, символические ссылки и прочие не обычные файлы тела не несут.
+		return nil
+	}
+
+	f, err := os.Open(fi.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// readTarEntries читает tar-поток из r, вызывая handler для каждой записи.
+func readTarEntries(ctx context.Context, r io.Reader, handler func(File) error) error {
+	tr := tar.NewReader(r)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := handler(File{
+			Name:     hdr.Name,
+			Mode:     hdr.FileInfo().Mode(),
+			ModTime:  hdr.ModTime,
+			Size:     hdr.Size,
+			IsDir:    hdr.Typeflag == tar.TypeDir,
+			Linkname: hdr.Linkname,
+			Reader:   tr,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// tarFormat — несжатый tar.
+type tarFormat struct{}
+
+func (tarFormat) Extension() string { return ".tar" }
+
+func (tarFormat) Match(magic []byte) bool {
+	return len(magic) >= 262 && string(magic[257:262]) == "ustar"
+}
+
+func (tarFormat) Archive(ctx context.Context, out io.Writer, files []FileInfo) error {
+	return writeTarEntries(ctx, out, files)
+}
+
+func (tarFormat) Extract(ctx context.Context, in io.Reader, handler func(File) error) error {
+	return readTarEntries(ctx, in, handler)
+}
@@ -0,0 +1,36 @@
+package archiver
+
+import (
+	"context"
+	"io"
+
+	"github.com/klauspost/pgzip"
+)
+
+// tarGzFormat — tar, сжатый gzip. Используем pgzip вместо стандартного
+// compress/gzip, чтобы сжатие распараллеливалось по блокам, а не шло одним потоком.
+type tarGzFormat struct{}
+
+func (tarGzFormat) Extension() string { return ".tar.gz" }
+
+func (tarGzFormat) Match(magic []byte) bool {
+	return len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+func (tarGzFormat) Archive(ctx context.Context, out io.Writer, files []FileInfo) error {
+	gw := pgzip.NewWriter(out)
+	if err := writeTarEntries(ctx, gw, files); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+func (tarGzFormat) Extract(ctx context.Context, in io.Reader, handler func(File) error) error {
+	gr, err := pgzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	return readTarEntries(ctx, gr, handler)
+}
@@ -0,0 +1,46 @@
+package archiver
+
+import (
+	"context"
+	"io"
+
+	"github.com/ulikunitz/xz"
+)
+
+// tarXzFormat — tar, сжатый xz.
+type tarXzFormat struct{}
+
+func (tarXzFormat) Extension() string { return ".tar.xz" }
+
+func (tarXzFormat) Match(magic []byte) bool {
+	xzMagic := []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	if len(magic) < len(xzMagic) {
+		return false
+	}
+	for i, b := range xzMagic {
+		if magic[i] != b {
+			return false
+		}
+	}
+	return true
+}
+
+func (tarXzFormat) Archive(ctx context.Context, out io.Writer, files []FileInfo) error {
+	xw, err := xz.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	if err := writeTarEntries(ctx, xw, files); err != nil {
+		xw.Close()
+		return err
+	}
+	return xw.Close()
+}
+
+func (tarXzFormat) Extract(ctx context.Context, in io.Reader, handler func(File) error) error {
+	xr, err := xz.NewReader(in)
+	if err != nil {
+		return err
+	}
+	return readTarEntries(ctx, xr, handler)
+}
@@ -0,0 +1,39 @@
+package archiver
+
+import (
+	"context"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarZstFormat — tar, сжатый zstd.
+type tarZstFormat struct{}
+
+func (tarZstFormat) Extension() string { return ".tar.zst" }
+
+func (tarZstFormat) Match(magic []byte) bool {
+	return len(magic) >= 4 &&
+		magic[0] == 0x28 && magic[1] == 0xb5 && magic[2] == 0x2f && magic[3] == 0xfd
+}
+
+func (tarZstFormat) Archive(ctx context.Context, out io.Writer, files []FileInfo) error {
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	if err := writeTarEntries(ctx, zw, files); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func (tarZstFormat) Extract(ctx context.Context, in io.Reader, handler func(File) error) error {
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return readTarEntries(ctx, zr, handler)
+}
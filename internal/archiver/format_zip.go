@@ -0,0 +1,86 @@
+package archiver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+)
+
+// zipFormat — реализация Format поверх Archiver, сохраняющая существующий
+// параллельный дизайн (включая блочное сжатие больших файлов из blockDeflate).
+type zipFormat struct{}
+
+func (zipFormat) Extension() string { return ".zip" }
+
+func (zipFormat) Match(magic []byte) bool {
+	return len(magic) >= 4 && magic[0] == 'P' && magic[1] == 'K' &&
+		(magic[2] == 3 || magic[2] == 5 || magic[2] == 7)
+}
+
+func (zipFormat) Archive(ctx context.Context, out io.Writer, files []FileInfo) error {
+	return NewArchiver(DefaultZipOptions()).ArchiveFiles(ctx, out, files)
+}
+
+func (zipFormat) Extract(ctx context.Context, in io.Reader, handler func(File) error) error {
+	ra, size, err := asReaderAt(in)
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = handler(File{
+			Name:    f.Name,
+			Mode:    f.Mode(),
+			ModTime: f.Modified,
+			Size:    int64(f.UncompressedSize64),
+			IsDir:   f.FileInfo().IsDir(),
+			Extra:   f.Extra,
+			Reader:  rc,
+		})
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asReaderAt приводит in к io.ReaderAt с известным размером, как того требует
+// zip.NewReader. Если in уже является io.ReaderAt и умеет Seek, размер
+// берётся оттуда; иначе поток целиком буферизуется в памяти.
+func asReaderAt(in io.Reader) (io.ReaderAt, int64, error) {
+	if ra, ok := in.(io.ReaderAt); ok {
+		if s, ok := in.(io.Seeker); ok {
+			size, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, 0, err
+			}
+			if _, err := s.Seek(0, io.SeekStart); err != nil {
+				return nil, 0, err
+			}
+			return ra, size, nil
+		}
+	}
+
+	buf, err := io.ReadAll(in)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(buf), int64(len(buf)), nil
+}
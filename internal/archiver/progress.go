@@ -0,0 +1,54 @@
+package archiver
+
+import (
+	"context"
+	"io"
+)
+
+// ProgressEvent описывает прогресс упаковки/распаковки архива в данный момент.
+type ProgressEvent struct {
+	// CurrentFile — запись, обработка которой только что завершилась.
+	CurrentFile string
+	// TotalFiles — общее число обычных файлов (без каталогов) в операции.
+	TotalFiles int
+	// FilesDone — сколько файлов уже обработано успешно, включая CurrentFile.
+	FilesDone int
+	// BytesDone — сколько несжатых байт уже обработано. Учитывается только
+	// после успешного завершения файла целиком, а не в момент постановки в очередь —
+	// иначе при ошибке на середине файла прогресс соврёт о том, что он "готов".
+	BytesDone int64
+	// TotalBytes — суммарный несжатый размер всех файлов операции.
+	TotalBytes int64
+}
+
+// Options управляет прогрессом и воспроизводимостью для *Context-вариантов
+// Zip/UnzipParallelAll.
+type Options struct {
+	// Progress, если задан, вызывается после успешного завершения каждого файла.
+	Progress func(ProgressEvent)
+	// Deterministic включает воспроизводимый режим упаковки: одинаковые
+	// входные файлы всегда дают побайтово одинаковый ZIP (см. ZipOptions и
+	// deterministicEpoch/normalizedPerm в determinism.go).
+	Deterministic bool
+	// Dedup включает дедупликацию одинаковых по размеру и содержимому (SHA-256)
+	// файлов: тело хранится только в первой (канонической) записи, остальные
+	// становятся пустыми записями со ссылкой на неё (см. dedup.go).
+	Dedup bool
+}
+
+// ctxReader оборачивает io.Reader, прерывая чтение с ctx.Err(), как только ctx
+// отменён. Нужен, чтобы io.Copy одного большого файла не блокировал отмену
+// до своего завершения.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	select {
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	default:
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,33 @@
+package archiver
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// needsUTF8Flag сообщает, нужно ли выставлять бит общего назначения 0x0800
+// (UTF-8 имя/комментарий) для записи с именем name: хотя бы один компонент
+// пути должен быть не ASCII-строкой, а каждый компонент — валидной
+// UTF-8-строкой.
+func needsUTF8Flag(name string) bool {
+	nonASCII := false
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || isASCII(part) {
+			continue
+		}
+		if !utf8.ValidString(part) {
+			return false
+		}
+		nonASCII = true
+	}
+	return nonASCII
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package archiver
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// WalkZip открывает zipPath и параллельно вызывает handler для каждой записи,
+// не извлекая архив на диск — удобно для подсчёта контрольных сумм,
+// фильтрации записей или заливки содержимого куда-то ещё. Использует тот же
+// пул воркеров, что и UnzipParallelAll, и уважает отмену через ctx (проверяется
+// перед постановкой каждой записи в работу).
+func WalkZip(ctx context.Context, zipPath string, handler func(f *zip.File, rc io.ReadCloser) error) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return walkZipFiles(ctx, r.File, handler)
+}
+
+// ArchiveFromReader читает zip-архив из произвольного ra размера size —
+// например, буфера в памяти или объекта в object storage — без
+// необходимости иметь архив в виде файла на диске, и вызывает handler для
+// каждой записи, как WalkZip.
+func ArchiveFromReader(ctx context.Context, ra io.ReaderAt, size int64, handler func(f *zip.File, rc io.ReadCloser) error) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	return walkZipFiles(ctx, zr.File, handler)
+}
+
+// walkZipFiles обрабатывает files пулом из runtime.NumCPU() воркеров (как
+// UnzipParallelAll), вызывая handler для каждой записи. Возвращает первую
+// встреченную ошибку (в том числе ctx.Err(), если ctx отменили); обработка
+// остальных записей при этом не прерывается досрочно — так же, как это уже
+// устроено в UnzipParallelAll.
+func walkZipFiles(ctx context.Context, files []*zip.File, handler func(f *zip.File, rc io.ReadCloser) error) error {
+	workers := runtime.NumCPU()
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	errOnce := make(chan error, 1)
+	setErr := func(e error) {
+		select {
+		case errOnce <- e:
+		default:
+		}
+	}
+
+	for _, f := range files {
+		wg.Add(1)
+		go func(f *zip.File) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			select {
+			case <-ctx.Done():
+				setErr(ctx.Err())
+				return
+			default:
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				setErr(err)
+				return
+			}
+			defer rc.Close()
+
+			if err := handler(f, rc); err != nil {
+				setErr(err)
+			}
+		}(f)
+	}
+	wg.Wait()
+
+	select {
+	case e := <-errOnce:
+		return e
+	default:
+		return nil
+	}
+}
+
+// WalkArchive открывает archivePath (формат определяется по расширению, а
+// если оно не распознано — по магическим байтам начала файла) и вызывает
+// handler для каждой записи потоково, без извлечения на диск. В отличие от
+// WalkZip работает для всех форматов из реестра Format.
+func WalkArchive(ctx context.Context, archivePath string, handler func(File) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format, r, err := DetectFormat(f, archivePath)
+	if err != nil {
+		return err
+	}
+
+	return format.Extract(ctx, r, handler)
+}
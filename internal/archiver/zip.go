@@ -2,6 +2,7 @@ package archiver
 
 import (
 	"archive/zip"
+	"context"
 	"errors"
 	"io"
 	"io/fs"
@@ -14,12 +15,35 @@ import (
 	"time"
 )
 
-// ZipParallelAll упаковывает src в zipPath, используя все доступные CPU для параллельной подготовки/чтения.
-// Запись в zip.Writer выполняется последовательно (как требует формат ZIP).
+// ZipParallelAll упаковывает src в zipPath с настройками по умолчанию.
+// Это тонкая обёртка над (*Archiver).ZipParallelAll.
 func ZipParallelAll(src, zipPath string) error {
-	src = filepath.Clean(src)
+	return NewArchiver(DefaultZipOptions()).ZipParallelAll(src, zipPath)
+}
+
+// ZipParallelAllContext упаковывает src в zipPath с настройками по умолчанию,
+// сообщая о прогрессе через opts.Progress и уважая отмену через ctx. Если
+// opts.Deterministic установлен, результат воспроизводим побайтово для
+// одинаковых входных файлов (см. Options.Deterministic).
+func ZipParallelAllContext(ctx context.Context, src, zipPath string, opts Options) error {
+	a := NewArchiver(DefaultZipOptions())
+	a.Progress = opts.Progress
+	a.Deterministic = opts.Deterministic
+	a.Dedup = opts.Dedup
+	return a.ZipParallelAllContext(ctx, src, zipPath)
+}
 
-	info, err := os.Stat(src)
+// ZipParallelAll собирает файлы из src и упаковывает их в zipPath.
+func (a *Archiver) ZipParallelAll(src, zipPath string) error {
+	return a.ZipParallelAllContext(context.Background(), src, zipPath)
+}
+
+// ZipParallelAllContext — как ZipParallelAll, но принимает ctx для отмены
+// (проверяется между файлами и внутри копирования данных файлов, сжимаемых
+// одним потоком; блочное сжатие крупных файлов в blockDeflate отмену не
+// проверяет) и сообщает о прогрессе через a.Progress, если он задан.
+func (a *Archiver) ZipParallelAllContext(ctx context.Context, src, zipPath string) error {
+	files, err := CollectFiles(src)
 	if err != nil {
 		return err
 	}
@@ -27,68 +51,89 @@ func ZipParallelAll(src, zipPath string) error {
 		return err
 	}
 
-	// Сбор путей (детерминированный порядок для стабильных архивов).
-	paths := make([]string, 0, 1024)
-	if err := filepath.WalkDir(src, func(p string, d fs.DirEntry, we error) error {
-		if we != nil {
-			return we
-		}
-		paths = append(paths, p)
-		return nil
-	}); err != nil {
-		return err
-	}
-	sort.Strings(paths)
-
 	out, err := os.Create(zipPath)
 	if err != nil {
 		return err
 	}
 	defer out.Close()
 
-	zw := zip.NewWriter(out)
-	defer zw.Close()
-
-	// baseDir — имя корня внутри архива для директории.
-	baseDir := ""
-	if info.IsDir() {
-		baseDir = filepath.Base(src)
+	if err := a.ArchiveFiles(ctx, out, files); err != nil {
+		return err
 	}
+	return out.Close()
+}
 
-	type result struct {
-		idx   int
-		hdr   *zip.FileHeader
-		write func(w io.Writer) error // nil для каталогов (пустые записи)
-		err   error
+// ArchiveFiles упаковывает уже собранные files в ZIP, записывая в out.
+// Заголовки готовятся параллельно (a.Options.Concurrency горутин), там же
+// блочно сжимаются файлы не меньше a.Options.MinParallelFileSize (см.
+// blockDeflate); запись в zip.Writer выполняется последовательно в исходном
+// порядке files, как того требует формат ZIP, и содержимое остальных файлов
+// читается и сжимается уже при этой последовательной записи.
+func (a *Archiver) ArchiveFiles(ctx context.Context, out io.Writer, files []FileInfo) error {
+	opts := a.Options.withDefaults()
+
+	if a.Deterministic {
+		// Порядок записей должен зависеть только от их имён внутри архива,
+		// а не от порядка обхода файловой системы.
+		sorted := make([]FileInfo, len(files))
+		copy(sorted, files)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].NameInArchive < sorted[j].NameInArchive })
+		files = sorted
 	}
 
-	// Вспомогательные функции (замыкания), чтобы держать всё в одной функции:
-	indexOf := func(arr []string, x string) int {
-		for i, v := range arr {
-			if v == x {
-				return i
-			}
+	var dupOf map[int]string
+	if a.Dedup {
+		var dErr error
+		dupOf, dErr = buildDedupIndex(ctx, files, opts.Concurrency)
+		if dErr != nil {
+			return dErr
 		}
-		return -1
-	}
-	toZipPath := func(p string) string {
-		return strings.ReplaceAll(p, string(os.PathSeparator), "/")
 	}
-	relInside := func(srcRoot, base, full string) string {
-		if base == "" { // один файл
-			return filepath.Base(full)
+
+	var totalBytes int64
+	totalFiles := 0
+	for _, fi := range files {
+		if !fi.Info.IsDir() {
+			totalBytes += fi.Info.Size()
+			totalFiles++
 		}
-		if full == srcRoot {
-			return base
+	}
+	var filesDone int
+	var bytesDone int64
+	reportProgress := func(fi FileInfo) {
+		if a.Progress == nil || fi.Info.IsDir() {
+			return
 		}
-		rel, _ := filepath.Rel(filepath.Dir(srcRoot), full)
-		return rel
+		filesDone++
+		bytesDone += fi.Info.Size()
+		a.Progress(ProgressEvent{
+			CurrentFile: fi.NameInArchive,
+			TotalFiles:  totalFiles,
+			FilesDone:   filesDone,
+			BytesDone:   bytesDone,
+			TotalBytes:  totalBytes,
+		})
+	}
+
+	zw := zip.NewWriter(out)
+	defer zw.Close()
+
+	type result struct {
+		idx              int
+		hdr              *zip.FileHeader
+		write            func(w io.Writer) error // nil для каталогов (пустые записи)
+		raw              bool                    // true — данные уже сжаты блоками (см. blockDeflate)
+		rawData          []byte
+		crc              uint32
+		uncompressedSize uint64
+		err              error
 	}
+
 	writeOne := func(r result) error {
-		if r.hdr == nil && r.write == nil {
-			return nil // корневой dir, ничего не пишем
+		if r.raw {
+			return writeRawDeflate(zw, r.hdr, r.rawData, r.crc, r.uncompressedSize)
 		}
-		if r.hdr != nil && r.write == nil {
+		if r.write == nil {
 			_, err := zw.CreateHeader(r.hdr)
 			return err
 		}
@@ -99,56 +144,92 @@ func ZipParallelAll(src, zipPath string) error {
 		return r.write(w)
 	}
 
-	jobs := runtime.NumCPU()
-	jobsCh := make(chan string, 2*jobs)
-	resCh := make(chan result, 2*jobs)
+	jobs := opts.Concurrency
+	if jobs > len(files) {
+		jobs = len(files)
+	}
+
+	jobsCh := make(chan int, 2*jobs+1)
+	resCh := make(chan result, 2*jobs+1)
 
-	// Воркеры: готовят заголовки и функцию записи.
+	// Воркеры: готовят заголовки и функцию записи (или готовое сжатое содержимое).
 	var wg sync.WaitGroup
 	wg.Add(jobs)
 	for i := 0; i < jobs; i++ {
 		go func() {
 			defer wg.Done()
-			for p := range jobsCh {
-				idx := indexOf(paths, p)
-				rel := relInside(src, baseDir, p)
+			for idx := range jobsCh {
+				fi := files[idx]
 
-				st, e := os.Stat(p)
+				hdr, e := zip.FileInfoHeader(fi.Info) // уже переносит ModTime и полный режим доступа (включая тип файла)
 				if e != nil {
 					resCh <- result{idx: idx, err: e}
 					continue
 				}
-				if st.IsDir() {
-					if rel != "" {
-						h := &zip.FileHeader{
-							Name:     toZipPath(rel) + "/",
-							Method:   zip.Store,
-							Modified: time.Now(), // можно st.ModTime()
-						}
-						resCh <- result{idx: idx, hdr: h}
-					} else {
-						resCh <- result{idx: idx} // корень директории
-					}
+				hdr.Name = fi.NameInArchive
+				hdr.NonUTF8 = false
+				if needsUTF8Flag(hdr.Name) {
+					hdr.Flags |= 0x0800
+				}
+
+				if a.Deterministic {
+					hdr.Modified = deterministicEpoch()
+					hdr.SetMode((fi.Info.Mode() & os.ModeType) | normalizedPerm(fi.Info.IsDir()))
+					hdr.Extra = nil
+				}
+
+				if canonicalName, isDup := dupOf[idx]; isDup {
+					hdr.Method = zip.Store
+					hdr.CRC32 = 0
+					hdr.UncompressedSize64 = 0
+					hdr.Extra = dedupExtraField(canonicalName)
+					resCh <- result{idx: idx, hdr: hdr}
 					continue
 				}
 
-				hdr, e := zip.FileInfoHeader(st)
-				if e != nil {
-					resCh <- result{idx: idx, err: e}
+				if fi.Info.IsDir() {
+					hdr.Name += "/"
+					hdr.Method = zip.Store
+					resCh <- result{idx: idx, hdr: hdr}
+					continue
+				}
+
+				if fi.Info.Mode()&os.ModeSymlink != 0 {
+					target, e := os.Readlink(fi.Path)
+					if e != nil {
+						resCh <- result{idx: idx, err: e}
+						continue
+					}
+					body := []byte(target)
+					hdr.Method = zip.Store
+					hdr.UncompressedSize64 = uint64(len(body))
+					resCh <- result{idx: idx, hdr: hdr, write: func(w io.Writer) error {
+						_, err := w.Write(body)
+						return err
+					}}
 					continue
 				}
-				hdr.Name = toZipPath(rel)
+
 				hdr.Method = zip.Deflate
-				hdr.Modified = time.Now()     // можно st.ModTime()
-				hdr.SetMode(st.Mode().Perm()) // сохранить права
 
+				if fi.Info.Size() >= opts.MinParallelFileSize {
+					compressed, crc, e := blockDeflate(fi.Path, fi.Info.Size(), opts)
+					if e != nil {
+						resCh <- result{idx: idx, err: e}
+						continue
+					}
+					resCh <- result{idx: idx, hdr: hdr, raw: true, rawData: compressed, crc: crc, uncompressedSize: uint64(fi.Info.Size())}
+					continue
+				}
+
+				path := fi.Path
 				writeFn := func(w io.Writer) error {
-					f, openErr := os.Open(p)
+					f, openErr := os.Open(path)
 					if openErr != nil {
 						return openErr
 					}
 					defer f.Close()
-					_, cpErr := io.Copy(w, f)
+					_, cpErr := io.Copy(w, ctxReader{ctx: ctx, r: f})
 					return cpErr
 				}
 
@@ -159,8 +240,8 @@ func ZipParallelAll(src, zipPath string) error {
 
 	// Подаём задания.
 	go func() {
-		for _, p := range paths {
-			jobsCh <- p
+		for i := range files {
+			jobsCh <- i
 		}
 		close(jobsCh)
 	}()
@@ -175,61 +256,46 @@ func ZipParallelAll(src, zipPath string) error {
 	next := 0
 	pending := make(map[int]result, 128)
 
-	for {
-		if r, ok := pending[next]; ok {
+	drain := func() error {
+		for {
+			r, ok := pending[next]
+			if !ok {
+				return nil
+			}
 			if r.err != nil {
 				return r.err
 			}
 			if err := writeOne(r); err != nil {
 				return err
 			}
+			reportProgress(files[r.idx]) // только после успешной записи — не заранее
 			delete(pending, next)
 			next++
-			continue
 		}
-		r, ok := <-resCh
-		if !ok {
-			// Канал закрыт — дожимаем оставшиеся по порядку.
-			for {
-				r2, ok2 := pending[next]
-				if !ok2 {
-					break
-				}
-				if r2.err != nil {
-					return r2.err
-				}
-				if err := writeOne(r2); err != nil {
-					return err
-				}
-				delete(pending, next)
-				next++
+	}
+
+	// abandonRest дочитывает resCh в фоне после досрочного выхода (ctx отменён
+	// или ошибка записи), иначе воркеры и фидер jobsCh останутся навсегда
+	// заблокированы на отправке в resCh/jobsCh — течь горутин для долгоживущего
+	// вызывающего кода.
+	abandonRest := func() {
+		go func() {
+			for range resCh {
 			}
-			break
+		}()
+	}
+
+	for r := range resCh {
+		select {
+		case <-ctx.Done():
+			abandonRest()
+			return ctx.Err()
+		default:
 		}
-		if r.idx == next {
-			if r.err != nil {
-				return r.err
-			}
-			if err := writeOne(r); err != nil {
-				return err
-			}
-			next++
-			for {
-				r2, ok2 := pending[next]
-				if !ok2 {
-					break
-				}
-				if r2.err != nil {
-					return r2.err
-				}
-				if err := writeOne(r2); err != nil {
-					return err
-				}
-				delete(pending, next)
-				next++
-			}
-		} else {
-			pending[r.idx] = r
+		pending[r.idx] = r
+		if err := drain(); err != nil {
+			abandonRest()
+			return err
 		}
 	}
 
@@ -237,10 +303,19 @@ func ZipParallelAll(src, zipPath string) error {
 	if err := zw.Close(); err != nil {
 		return err
 	}
-	return out.Close()
+	return nil
 }
 
+// UnzipParallelAll распаковывает zipPath в destDir с настройками по умолчанию.
 func UnzipParallelAll(zipPath, destDir string) error {
+	return UnzipParallelAllContext(context.Background(), zipPath, destDir, Options{})
+}
+
+// UnzipParallelAllContext — как UnzipParallelAll, но принимает ctx для отмены
+// (проверяется перед каждым файлом и внутри копирования его данных) и
+// сообщает о прогрессе через opts.Progress после успешного завершения
+// каждого файла — байты засчитываются только по факту, а не при постановке в очередь.
+func UnzipParallelAllContext(ctx context.Context, zipPath, destDir string, opts Options) error {
 	// Открываем архив
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
@@ -267,6 +342,35 @@ func UnzipParallelAll(zipPath, destDir string) error {
 		}
 	}
 
+	var totalBytes int64
+	totalFiles := 0
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() {
+			totalBytes += int64(f.UncompressedSize64)
+			totalFiles++
+		}
+	}
+	var progressMu sync.Mutex
+	var filesDone int
+	var bytesDone int64
+	reportProgress := func(f *zip.File) {
+		if opts.Progress == nil {
+			return
+		}
+		progressMu.Lock()
+		filesDone++
+		bytesDone += int64(f.UncompressedSize64)
+		ev := ProgressEvent{
+			CurrentFile: f.Name,
+			TotalFiles:  totalFiles,
+			FilesDone:   filesDone,
+			BytesDone:   bytesDone,
+			TotalBytes:  totalBytes,
+		}
+		progressMu.Unlock()
+		opts.Progress(ev)
+	}
+
 	// Параллельно распакуем файлы
 	workers := runtime.NumCPU()
 	sem := make(chan struct{}, workers) // семафор параллельности
@@ -278,10 +382,18 @@ func UnzipParallelAll(zipPath, destDir string) error {
 		select { case errOnce <- e: default: }
 	}
 
+	// Записи-дубликаты (Options.Dedup при упаковке, см. dedup.go) откладываем
+	// на отдельный проход после основного: материализовать их можно только
+	// когда канонический файл уже лежит на диске.
+	var dedupEntries []*zip.File
 	for _, f := range r.File {
 		if f.FileInfo().IsDir() {
 			continue
 		}
+		if _, ok := parseDedupExtra(f.Extra); ok {
+			dedupEntries = append(dedupEntries, f)
+			continue
+		}
 
 		wg.Add(1)
 		go func(f *zip.File) {
@@ -291,6 +403,13 @@ func UnzipParallelAll(zipPath, destDir string) error {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
+			select {
+			case <-ctx.Done():
+				setErr(ctx.Err())
+				return
+			default:
+			}
+
 			target := filepath.Join(destDir, f.Name)
 
 			// zip-slip защита
@@ -312,6 +431,34 @@ func UnzipParallelAll(zipPath, destDir string) error {
 			}
 			defer rc.Close()
 
+			if f.Mode()&os.ModeSymlink != 0 {
+				linkTarget, err := io.ReadAll(ctxReader{ctx: ctx, r: rc})
+				if err != nil {
+					setErr(err)
+					return
+				}
+
+				// Символическая ссылка может указывать за пределы destDir тем же
+				// способом, что и сама запись (zip-slip) — проверяем разрешённый путь.
+				resolved := string(linkTarget)
+				if !filepath.IsAbs(resolved) {
+					resolved = filepath.Join(filepath.Dir(target), resolved)
+				}
+				if err := ensureInside(destDir, resolved); err != nil {
+					setErr(err)
+					return
+				}
+
+				_ = os.Remove(target) // на случай повторной распаковки поверх старой ссылки/файла
+				if err := os.Symlink(string(linkTarget), target); err != nil {
+					setErr(err)
+					return
+				}
+
+				reportProgress(f)
+				return
+			}
+
 			// Создаём файл с исходными правами
 			dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
 			if err != nil {
@@ -320,7 +467,7 @@ func UnzipParallelAll(zipPath, destDir string) error {
 			}
 
 			// Копируем содержимое
-			if _, err := io.Copy(dst, rc); err != nil {
+			if _, err := io.Copy(dst, ctxReader{ctx: ctx, r: rc}); err != nil {
 				dst.Close()
 				setErr(err)
 				return
@@ -332,6 +479,8 @@ func UnzipParallelAll(zipPath, destDir string) error {
 
 			// Восстановим mtime (atime ставим текущее)
 			_ = os.Chtimes(target, time.Now(), f.Modified)
+
+			reportProgress(f) // только после успешного завершения файла
 		}(f)
 	}
 
@@ -343,8 +492,19 @@ func UnzipParallelAll(zipPath, destDir string) error {
 	case e := <-errOnce:
 		return e
 	default:
-		return nil
 	}
+
+	// Второй проход: раскладываем дубликаты теперь, когда канонические файлы уже на диске.
+	if len(dedupEntries) > 0 {
+		if err := materializeDedupEntries(destDir, dedupEntries); err != nil {
+			return err
+		}
+		for _, f := range dedupEntries {
+			reportProgress(f)
+		}
+	}
+
+	return nil
 }
 
 // ensureInside проверяет, что target находится внутри baseDir (защита от zip-slip).